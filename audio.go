@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+
+	"github.com/andrepcg/chip-8-emu/chip8"
+)
+
+const sampleRate = 44100
+
+// raylibBeeper plays the sound-timer beep through a raylib audio stream. By
+// default it emits a plain 440Hz square wave; once an XO-CHIP ROM loads a
+// pattern via F002/Fx3A, it streams that 1-bit pattern buffer at the
+// requested pitch instead.
+type raylibBeeper struct {
+	stream  rl.AudioStream
+	playing bool
+	phase   float64
+
+	pattern    [16]byte
+	usePattern bool
+	patternHz  float64
+}
+
+func newRaylibBeeper() *raylibBeeper {
+	rl.InitAudioDevice()
+	stream := rl.InitAudioStream(sampleRate, 16, 1)
+	return &raylibBeeper{stream: stream, patternHz: chip8.PatternPlaybackRate(64)}
+}
+
+func (b *raylibBeeper) Close() {
+	rl.StopAudioStream(b.stream)
+	rl.UnloadAudioStream(b.stream)
+	rl.CloseAudioDevice()
+}
+
+func (b *raylibBeeper) Start() {
+	if !b.playing {
+		rl.PlayAudioStream(b.stream)
+		b.playing = true
+	}
+}
+
+func (b *raylibBeeper) Stop() {
+	if b.playing {
+		rl.StopAudioStream(b.stream)
+		b.playing = false
+	}
+}
+
+func (b *raylibBeeper) SetPattern(pattern [16]byte, pitch byte) {
+	b.pattern = pattern
+	b.patternHz = chip8.PatternPlaybackRate(pitch)
+	b.usePattern = true
+}
+
+// Feed tops up the audio stream's buffer once raylib has drained it. Call
+// this once per frame; it is a no-op while nothing is playing.
+func (b *raylibBeeper) Feed() {
+	if !b.playing || !rl.IsAudioStreamProcessed(b.stream) {
+		return
+	}
+
+	const frames = sampleRate / 60
+	samples := make([]int16, frames)
+
+	if b.usePattern {
+		for i := range samples {
+			b.phase += b.patternHz / sampleRate
+			bitIndex := int(b.phase) % 128
+			byteIndex := bitIndex / 8
+			bit := 7 - bitIndex%8
+			if (b.pattern[byteIndex]>>uint(bit))&1 != 0 {
+				samples[i] = 8000
+			} else {
+				samples[i] = -8000
+			}
+		}
+	} else {
+		const toneHz = 440.0
+		for i := range samples {
+			b.phase += toneHz / sampleRate
+			if math.Mod(b.phase, 1.0) < 0.5 {
+				samples[i] = 8000
+			} else {
+				samples[i] = -8000
+			}
+		}
+	}
+
+	rl.UpdateAudioStream(b.stream, samples)
+}
@@ -1,6 +1,8 @@
 package chip8
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	"math/rand"
 	"os"
@@ -12,32 +14,246 @@ const (
 	FB_HEIGHT           = 32
 	CHIP8_PROGRAM_START = 0x200
 	DIGITS_LEN          = 5
-	OPERATIONS_PER_SEC  = 16
 )
 
-type Chip8 struct {
+// Display receives the emulator's framebuffer once per frame. Each entry is
+// a plane bitmask (bit0/bit1 select XO-CHIP colour planes, set independently
+// by Fx01/Dxyn; classic CHIP-8 and SCHIP only ever set bit0). width/height
+// describe the current resolution, which changes at runtime in SCHIP/
+// XO-CHIP hi-res mode.
+type Display interface {
+	Draw(framebuffer []byte, width, height int)
+}
+
+// Keypad reports the current state of the 16-key CHIP-8 hex keypad. Frontends
+// translate their own input (keyboard, gamepad, touch) into the 0x0-0xF key
+// values expected by the interpreter.
+type Keypad interface {
+	PressedKeys() []byte
+}
+
+// Beeper is driven by the sound timer: Start is called when ST transitions
+// from 0 to non-zero, Stop when it reaches 0 again. SetPattern is called
+// whenever an XO-CHIP ROM loads a new audio pattern buffer or pitch (F002,
+// Fx3A); implementations that only play a fixed tone can treat it as a
+// no-op.
+type Beeper interface {
+	Start()
+	Stop()
+	SetPattern(pattern [16]byte, pitch byte)
+}
+
+// NullBeeper discards every call. It satisfies Beeper for headless use —
+// tests, benchmarks, and any frontend that doesn't want audio — without
+// pulling in a rendering/audio backend just to drive the VM. The real
+// backend, raylibBeeper, lives in package main's audio.go, next to
+// raylibDisplay and raylibKeypad in main.go; this is only the no-op sibling that keeps the VM runnable
+// without it.
+type NullBeeper struct{}
+
+func (NullBeeper) Start()                                  {}
+func (NullBeeper) Stop()                                   {}
+func (NullBeeper) SetPattern(pattern [16]byte, pitch byte) {}
+
+// Config holds the parameters needed to bring up a VM. RomPath is relative
+// to the roms/ directory under the working directory; FontPath is relative
+// to the working directory itself.
+type Config struct {
+	RomPath  string
+	FontPath string
+	// Variant selects the instruction set/extensions to emulate. Defaults
+	// to ChipClassic.
+	Variant Variant
+	// Quirks overrides the default quirks preset for Variant. Leave nil to
+	// use DefaultQuirks(Variant).
+	Quirks *Quirks
+	// QuirksDir, if set, is checked for a per-ROM quirks sidecar file (see
+	// LoadQuirksProfile) keyed by the ROM's SHA-1, applied on top of Quirks.
+	QuirksDir string
+	// IPF is the number of instructions executed per 60Hz frame (RunFrame
+	// call). Zero selects DefaultIPF(Variant).
+	IPF int
+}
+
+// VM is the CHIP-8 interpreter: CPU, memory, framebuffer and keypad state.
+// It has no knowledge of how it is rendered or played back; frontends plug
+// in a Display/Keypad/Beeper to drive it.
+type VM struct {
 	V           [16]byte
 	I, PC       uint16
 	SP, DT, ST  uint8
 	STACK       [16]uint16
-	FRAMEBUFFER [FB_WIDTH * FB_HEIGHT]bool
-	RAM         [4096]byte
+	FRAMEBUFFER []byte
+	RAM         []byte
 	KEYBOARD    uint16
+
+	Variant Variant
+	Quirks  Quirks
+	Hires   bool
+	RPL     [16]byte
+	Planes  byte
+	Halted  bool
+
+	// IPF is how many instructions RunFrame executes per call.
+	IPF int
+	// WaitingForKey is true between a Fx0A fetch and the release of the
+	// key it is waiting on; the fetch/decode loop does not advance while
+	// this is set.
+	WaitingForKey bool
+	waitRegister  uint16
+	prevKeyboard  uint16
+
+	// PatternBuffer and Pitch hold the XO-CHIP audio pattern state set by
+	// F002/Fx3A. Pitch 64 is the ROM's sample rate baseline (4000Hz).
+	PatternBuffer [16]byte
+	Pitch         byte
+	patternDirty  bool
+	audioPlaying  bool
+
+	// drewThisFrame tracks whether Dxyn has run this frame, for the
+	// DisplayWait quirk below.
+	drewThisFrame bool
+}
+
+// vmGobState mirrors VM for gob encoding. encoding/gob silently drops
+// unexported fields, but several of VM's are load-bearing for SaveState
+// (waitRegister, prevKeyboard, patternDirty, audioPlaying, drewThisFrame) —
+// losing them mid-save would e.g. resolve a pending Fx0A into the wrong
+// register on reload. GobEncode/GobDecode below route through this struct
+// instead of letting gob see *VM directly.
+type vmGobState struct {
+	V             [16]byte
+	I, PC         uint16
+	SP, DT, ST    uint8
+	STACK         [16]uint16
+	FRAMEBUFFER   []byte
+	RAM           []byte
+	KEYBOARD      uint16
+	Variant       Variant
+	Quirks        Quirks
+	Hires         bool
+	RPL           [16]byte
+	Planes        byte
+	Halted        bool
+	IPF           int
+	WaitingForKey bool
+	WaitRegister  uint16
+	PrevKeyboard  uint16
+	PatternBuffer [16]byte
+	Pitch         byte
+	PatternDirty  bool
+	AudioPlaying  bool
+	DrewThisFrame bool
+}
+
+// GobEncode implements gob.GobEncoder so SaveState also captures the
+// unexported scheduler state vmGobState documents.
+func (cpu *VM) GobEncode() ([]byte, error) {
+	state := vmGobState{
+		V: cpu.V, I: cpu.I, PC: cpu.PC, SP: cpu.SP, DT: cpu.DT, ST: cpu.ST,
+		STACK: cpu.STACK, FRAMEBUFFER: cpu.FRAMEBUFFER, RAM: cpu.RAM, KEYBOARD: cpu.KEYBOARD,
+		Variant: cpu.Variant, Quirks: cpu.Quirks, Hires: cpu.Hires, RPL: cpu.RPL,
+		Planes: cpu.Planes, Halted: cpu.Halted, IPF: cpu.IPF,
+		WaitingForKey: cpu.WaitingForKey, WaitRegister: cpu.waitRegister, PrevKeyboard: cpu.prevKeyboard,
+		PatternBuffer: cpu.PatternBuffer, Pitch: cpu.Pitch, PatternDirty: cpu.patternDirty,
+		AudioPlaying: cpu.audioPlaying, DrewThisFrame: cpu.drewThisFrame,
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder, the inverse of GobEncode.
+func (cpu *VM) GobDecode(data []byte) error {
+	var state vmGobState
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&state); err != nil {
+		return err
+	}
+
+	cpu.V, cpu.I, cpu.PC = state.V, state.I, state.PC
+	cpu.SP, cpu.DT, cpu.ST = state.SP, state.DT, state.ST
+	cpu.STACK, cpu.FRAMEBUFFER, cpu.RAM, cpu.KEYBOARD = state.STACK, state.FRAMEBUFFER, state.RAM, state.KEYBOARD
+	cpu.Variant, cpu.Quirks, cpu.Hires = state.Variant, state.Quirks, state.Hires
+	cpu.RPL, cpu.Planes, cpu.Halted, cpu.IPF = state.RPL, state.Planes, state.Halted, state.IPF
+	cpu.WaitingForKey, cpu.waitRegister, cpu.prevKeyboard = state.WaitingForKey, state.WaitRegister, state.PrevKeyboard
+	cpu.PatternBuffer, cpu.Pitch = state.PatternBuffer, state.Pitch
+	cpu.patternDirty, cpu.audioPlaying, cpu.drewThisFrame = state.PatternDirty, state.AudioPlaying, state.DrewThisFrame
+	return nil
+}
+
+// New creates a VM and loads the font and ROM described by cfg.
+func New(cfg Config) *VM {
+	cpu := &VM{}
+	cpu.Initialize(cfg)
+	return cpu
 }
 
-func (cpu *Chip8) Initialize(romPath string) {
-	// Load digits
+// Reset reinitializes the VM with cfg, e.g. to load a different ROM or
+// restart the current one. Unlike New, it carries the RPL flag registers
+// (Fx75/Fx85) over from before the reset: on real SCHIP calculators these
+// lived in battery-backed storage and survived a reset, and ROMs that use
+// them to remember state across restarts rely on that. The rest of the
+// SCHIP/XO-CHIP instruction set Reset's callers rely on — hi-res mode,
+// scrolling, RPL storage itself — lives in variant.go, not here.
+func (cpu *VM) Reset(cfg Config) {
+	rpl := cpu.RPL
+	cpu.Initialize(cfg)
+	cpu.RPL = rpl
+}
+
+func (cpu *VM) Initialize(cfg Config) {
+	cpu.Variant = cfg.Variant
+	cpu.Planes = 1
+	cpu.Pitch = 64
+
+	if cfg.Quirks != nil {
+		cpu.Quirks = *cfg.Quirks
+	} else {
+		cpu.Quirks = DefaultQuirks(cpu.Variant)
+	}
+
+	cpu.IPF = cfg.IPF
+	if cpu.IPF == 0 {
+		cpu.IPF = DefaultIPF(cpu.Variant)
+	}
+
+	ramSize := ClassicRamSize
+	if cpu.Variant == XOChip {
+		ramSize = XORamSize
+	}
+	cpu.RAM = make([]byte, ramSize)
+	cpu.setResolution(false)
+
+	fontPath := cfg.FontPath
+	if fontPath == "" {
+		fontPath = "chip8/digits.rom"
+	}
+
 	println("Loading digits")
-	digits := LoadRomFromFile("chip8/digits.rom")
+	digits := LoadRomFromFile(fontPath)
 	cpu.LoadRom(digits, 0)
 
-	rom := LoadRomFromFile(path.Join("roms", romPath))
+	if cpu.Variant != ChipClassic {
+		cpu.LoadRom(BIG_DIGITS[:], BigDigitsOffset)
+	}
+
+	rom := LoadRomFromFile(path.Join("roms", cfg.RomPath))
 
-	// Load ROM
 	fmt.Printf("Loading ROM with size %d bytes\n", len(rom))
 	cpu.LoadRom(rom, CHIP8_PROGRAM_START)
 	cpu.PC = CHIP8_PROGRAM_START
 
+	if cfg.QuirksDir != "" {
+		if q, err := LoadQuirksProfile(cfg.QuirksDir, rom, cpu.Quirks); err == nil {
+			cpu.Quirks = q
+		} else {
+			fmt.Printf("Failed to load quirks profile: %v\n", err)
+		}
+	}
+
 	println("Initialization complete")
 }
 
@@ -55,11 +271,11 @@ func LoadRomFromFile(filePath string) []byte {
 	return dat
 }
 
-func (cpu *Chip8) LoadRom(rom []byte, location uint16) {
+func (cpu *VM) LoadRom(rom []byte, location uint16) {
 	copy(cpu.RAM[location:], rom[:])
 }
 
-func (cpu *Chip8) PrintDebugCompact() {
+func (cpu *VM) PrintDebugCompact() {
 	fmt.Printf("PC:0x%04X I:0x%04X SP:%d DT:%d ST:%d | ", cpu.PC, cpu.I, cpu.SP, cpu.DT, cpu.ST)
 	fmt.Print("V:")
 	for i := 0; i < 16; i++ {
@@ -72,18 +288,18 @@ func (cpu *Chip8) PrintDebugCompact() {
 	fmt.Println()
 }
 
-func (cpu *Chip8) IsKeyPressed(key byte) bool {
+func (cpu *VM) IsKeyPressed(key byte) bool {
 	if key > 15 {
 		return false
 	}
 	return (cpu.KEYBOARD>>uint16(16-1-key))&0x1 > 0
 }
 
-func (cpu *Chip8) IsAnyKeyPressed() bool {
+func (cpu *VM) IsAnyKeyPressed() bool {
 	return cpu.KEYBOARD > 0
 }
 
-func (cpu *Chip8) PressedKeys() []uint8 {
+func (cpu *VM) PressedKeys() []uint8 {
 	var keys []uint8
 
 	for k := byte(0x0); k < 0xF; k++ {
@@ -95,25 +311,29 @@ func (cpu *Chip8) PressedKeys() []uint8 {
 	return keys
 }
 
-func (cpu *Chip8) UpdateKeyboard(pressedKeys []byte) {
+// UpdateKeyboard records which keys are currently held, as reported by a
+// Keypad implementation. It should be called once per frame, before
+// RunFrame/Step, so that Fx0A can detect key-release edges.
+func (cpu *VM) UpdateKeyboard(pressedKeys []byte) {
+	cpu.prevKeyboard = cpu.KEYBOARD
 	cpu.KEYBOARD = 0
 	for _, v := range pressedKeys {
 		cpu.KEYBOARD |= uint16(1) << (16 - 1 - v)
 	}
 }
 
-func (cpu *Chip8) StackPush(value uint16) {
+func (cpu *VM) StackPush(value uint16) {
 	cpu.STACK[cpu.SP] = value
 	cpu.SP += 1
 }
 
-func (cpu *Chip8) StackPop() uint16 {
+func (cpu *VM) StackPop() uint16 {
 	cpu.SP -= 1
 	v := cpu.STACK[cpu.SP]
 	return v
 }
 
-func (cpu *Chip8) Fetch() uint16 {
+func (cpu *VM) Fetch() uint16 {
 	if cpu.PC < CHIP8_PROGRAM_START {
 		fmt.Printf("PC = 0x%x\n", cpu.PC)
 		panic("CORRUPTED PROGRAM COUNTER")
@@ -125,37 +345,51 @@ func (cpu *Chip8) Fetch() uint16 {
 	return (byte1 << 8) | byte2
 }
 
-func (cpu *Chip8) DrawSprite(vx, vy byte, bytes []byte) {
+func (cpu *VM) DrawSprite(vx, vy byte, bytes []byte) {
 	// These bytes are then displayed as sprites on screen at coordinates (Vx, Vy).
 	// Sprites are XORed onto the existing screen. If this causes any pixels to be erased, VF is set to 1,
 	// otherwise it is set to 0. If the sprite is positioned so part of it is outside the coordinates of the display,
 	// it wraps around to the opposite side of the screen.
+	// Under the ClipSprites quirk, pixels past the right or bottom edge are dropped instead of wrapped.
 
+	w, h := cpu.Width(), cpu.Height()
 	cpu.V[0xF] = 0
 
 	for dy, v := range bytes {
 		for index := byte(0); index < 8; index += 1 {
 			dx := 8 - 1 - index
 			pixel := ((v >> dx) & 1) > 0
-			x := uint16(vx+index) % FB_WIDTH
-			y := uint16(vy+byte(dy)) % FB_HEIGHT
-			loc := int(y)*FB_WIDTH + int(x)
-			old := cpu.FRAMEBUFFER[loc]
-
-			if pixel {
-				if old {
-					cpu.V[0xF] = 1
+			if !pixel {
+				continue
+			}
+
+			x := int(vx) + int(index)
+			y := int(vy) + dy
+
+			if cpu.Quirks.ClipSprites {
+				if x >= w || y >= h {
+					continue
 				}
-				cpu.FRAMEBUFFER[loc] = !old
+			} else {
+				x %= w
+				y %= h
+			}
+
+			loc := y*w + x
+			if cpu.FRAMEBUFFER[loc] != 0 {
+				cpu.V[0xF] = 1
+				cpu.FRAMEBUFFER[loc] = 0
+			} else {
+				cpu.FRAMEBUFFER[loc] = 1
 			}
 		}
 	}
 }
 
-func (cpu *Chip8) DecodeExecute(instruction uint16) {
+func (cpu *VM) DecodeExecute(instruction uint16) {
 	switch instruction {
 	case 0x00E0:
-		clear(cpu.FRAMEBUFFER[:])
+		cpu.clearPlanes()
 		return
 	case 0x00EE:
 		// The interpreter sets the program counter to the address at the top of the stack, then subtracts 1 from the stack pointer.
@@ -166,8 +400,6 @@ func (cpu *Chip8) DecodeExecute(instruction uint16) {
 	x := instruction & 0x0F00 >> 8
 	y := instruction & 0x00F0 >> 4
 
-	// fmt.Printf("Cur instruction 0x%x\n", instruction)
-
 	if instruction&0xF000 == 0x1000 { // 1nnn - JP addr
 		cpu.PC = instruction & 0x0FFF
 	} else if instruction&0xF000 == 0x2000 { // 2nnn - CALL addr
@@ -203,10 +435,19 @@ func (cpu *Chip8) DecodeExecute(instruction uint16) {
 		// bitwise OR compares the corresponding bits from two values, and if either bit is 1, then the same bit in the
 		// result is also 1. Otherwise, it is 0.
 		cpu.V[x] = cpu.V[x] | cpu.V[y]
+		if cpu.Quirks.LogicResetVF {
+			cpu.V[0xF] = 0
+		}
 	} else if instruction&0xF00F == 0x8002 { // 8xy2 - AND Vx, Vy
 		cpu.V[x] = cpu.V[x] & cpu.V[y]
+		if cpu.Quirks.LogicResetVF {
+			cpu.V[0xF] = 0
+		}
 	} else if instruction&0xF00F == 0x8003 { // 8xy3 - XOR Vx, Vy
 		cpu.V[x] = cpu.V[x] ^ cpu.V[y]
+		if cpu.Quirks.LogicResetVF {
+			cpu.V[0xF] = 0
+		}
 	} else if instruction&0xF00F == 0x8004 { // 8xy4 - ADD Vx, Vy
 		// Set Vx = Vx + Vy, set VF = carry. The values of Vx and Vy are added together. If the result is greater
 		// than 8 bits (i.e., ¿ 255,) VF is set to 1, otherwise 0. Only the lowest 8 bits of the result are kept, and stored in Vx.
@@ -231,9 +472,13 @@ func (cpu *Chip8) DecodeExecute(instruction uint16) {
 		}
 	} else if instruction&0xF00F == 0x8006 { // 8xy6 - SHR Vx {, Vy}
 		// Set Vx = Vx SHR 1. If the least-significant bit of Vx is 1, then VF is set to 1, otherwise 0. Then Vx is divided by 2.
-		vx := cpu.V[x]
-		cpu.V[x] = vx >> 1
-		cpu.V[0xF] = vx & 0x01
+		// ShiftUsesVy (COSMAC VIP): shift Vy into Vx first instead of shifting Vx in place.
+		src := cpu.V[x]
+		if cpu.Quirks.ShiftUsesVy {
+			src = cpu.V[y]
+		}
+		cpu.V[x] = src >> 1
+		cpu.V[0xF] = src & 0x01
 	} else if instruction&0xF00F == 0x8007 { // 8xy7 - SUBN Vx, Vy
 		// Set Vx = Vy - Vx, set VF = NOT borrow.
 		// If Vy >= Vx, then VF is set to 1, otherwise 0. Then Vx is subtracted from Vy, and the results stored in Vx.
@@ -248,9 +493,12 @@ func (cpu *Chip8) DecodeExecute(instruction uint16) {
 	} else if instruction&0xF00F == 0x800E { // 8xyE - SHL Vx {, Vy}
 		// Set Vx = Vx SHL 1.
 		// If the most-significant bit of Vx is 1, then VF is set to 1, otherwise to 0. Then Vx is multiplied by 2.
-		vx := cpu.V[x]
-		cpu.V[x] = vx << 1
-		cpu.V[0xF] = (vx >> 7) & 0x01
+		src := cpu.V[x]
+		if cpu.Quirks.ShiftUsesVy {
+			src = cpu.V[y]
+		}
+		cpu.V[x] = src << 1
+		cpu.V[0xF] = (src >> 7) & 0x01
 	} else if instruction&0xF00F == 0x9000 { // 9xy0 - SNE Vx, Vy
 		// Skip next instruction if Vx != Vy.
 		// The values of Vx and Vy are compared, and if they are not equal, the program counter is increased by 2.
@@ -261,8 +509,13 @@ func (cpu *Chip8) DecodeExecute(instruction uint16) {
 		// Set I = nnn.
 		cpu.I = instruction & 0x0FFF
 	} else if instruction&0xF000 == 0xB000 { // Bnnn - JP V0, addr
-		// The program counter is set to nnn plus the value of V0.
-		cpu.PC = instruction&0x0FFF + uint16(cpu.V[0])
+		// The program counter is set to nnn plus the value of V0 (or, under
+		// JumpWithVx, xnn plus Vx — the SCHIP interpretation).
+		if cpu.Quirks.JumpWithVx {
+			cpu.PC = instruction&0x0FFF + uint16(cpu.V[x])
+		} else {
+			cpu.PC = instruction&0x0FFF + uint16(cpu.V[0])
+		}
 	} else if instruction&0xF000 == 0xC000 { // Cxkk - RND Vx, byte
 		// The interpreter generates a random number from 0 to 255, which is then ANDed with the value kk. The results are stored in Vx.
 		cpu.V[x] = byte(rand.Intn(256)) & byte(instruction&0x00FF)
@@ -274,7 +527,16 @@ func (cpu *Chip8) DecodeExecute(instruction uint16) {
 		// it wraps around to the opposite side of the screen.
 
 		n := instruction & 0x000F
-		cpu.DrawSprite(cpu.V[x], cpu.V[y], cpu.RAM[cpu.I:(cpu.I+n)])
+		// Every sprite drawer below clips instead of wrapping under the ClipSprites quirk.
+		switch {
+		case cpu.Variant == XOChip:
+			cpu.drawSpriteXOChip(cpu.V[x], cpu.V[y], n)
+		case cpu.Variant != ChipClassic && (n == 0 || cpu.Hires):
+			cpu.drawSpriteExtended(cpu.V[x], cpu.V[y], n)
+		default:
+			cpu.DrawSprite(cpu.V[x], cpu.V[y], cpu.RAM[cpu.I:(cpu.I+n)])
+		}
+		cpu.drewThisFrame = true
 	} else if instruction&0xF0FF == 0xE09E { // Ex9E - SKP Vx
 		// Skip next instruction if key with the value of Vx is pressed.
 		// Checks the keyboard, and if the key corresponding to the value of Vx is currently in the down position, PC is increased by 2.
@@ -290,13 +552,13 @@ func (cpu *Chip8) DecodeExecute(instruction uint16) {
 		// The value of DT is placed into Vx.
 		cpu.V[x] = cpu.DT
 	} else if instruction&0xF0FF == 0xF00A { // Fx0A - LD Vx, K
-		// All execution stops until a key is pressed, then the value of that key is stored in Vx.
-
-		for !cpu.IsAnyKeyPressed() {
-		} // busy wait
-
-		// FIXME: this is not totally correct
-		cpu.V[x] = cpu.PressedKeys()[0]
+		// All execution stops until a key is pressed and released, then the
+		// value of that key is stored in Vx. Rather than busy-waiting here,
+		// we record which register is waiting and let Step poll for the
+		// release edge on every subsequent call, so timers and host input
+		// keep running while a ROM waits on a keypress.
+		cpu.WaitingForKey = true
+		cpu.waitRegister = x
 	} else if instruction&0xF0FF == 0xF015 { // Fx15 - LD DT, Vx
 		// DT is set equal to the value of Vx.
 		cpu.DT = cpu.V[x]
@@ -319,14 +581,24 @@ func (cpu *Chip8) DecodeExecute(instruction uint16) {
 	} else if instruction&0xF0FF == 0xF055 { // Fx55 - LD [I], Vx
 		// The interpreter copies the values of registers V0 through Vx into memory, starting at the address in I.
 		copy(cpu.RAM[cpu.I:cpu.I+x+1], cpu.V[0:x+1])
+		if cpu.Quirks.LoadStoreIncrementsI {
+			cpu.I += x + 1
+		}
 	} else if instruction&0xF0FF == 0xF065 { // Fx65 - LD Vx, [I]
 		// The interpreter reads values from memory starting at location I into registers V0 through Vx.
 		copy(cpu.V[0:x+1], cpu.RAM[cpu.I:cpu.I+x+1])
+		if cpu.Quirks.LoadStoreIncrementsI {
+			cpu.I += x + 1
+		}
+	} else {
+		cpu.decodeExtended(instruction)
 	}
 
 }
 
-func (cpu *Chip8) UpdateTimers() {
+// TickTimers decrements DT/ST by one. Call this once per 60Hz frame,
+// independently of how many instructions RunFrame executes that frame.
+func (cpu *VM) TickTimers() {
 	if cpu.ST > 0 {
 		cpu.ST -= 1
 	}
@@ -336,7 +608,91 @@ func (cpu *Chip8) UpdateTimers() {
 	}
 }
 
-func (cpu *Chip8) Step() {
+// Step executes a single instruction, or, while WaitingForKey is set,
+// checks whether the awaited key has been released.
+func (cpu *VM) Step() {
+	if cpu.WaitingForKey {
+		cpu.resolveKeyWait()
+		return
+	}
+
 	instruction := cpu.Fetch()
 	cpu.DecodeExecute(instruction)
 }
+
+// RunFrame executes up to instructionsPerFrame Step calls, stopping early
+// if the program halts (00FD EXIT) or, under the DisplayWait quirk, once a
+// Dxyn has drawn this frame — the original COSMAC VIP synced drawing to
+// vertical blank and ran at most one sprite draw per 60Hz frame. Call
+// TickTimers once per frame alongside this, not once per instruction, to
+// keep DT/ST at 60Hz regardless of CPU speed. The DisplayWait early-return
+// only decides when to stop feeding Step; Step's own Fx0A release-edge wait
+// (WaitingForKey/resolveKeyWait, below) still works the same whether it's
+// driven by this loop or by a single StepInto.
+func (cpu *VM) RunFrame(instructionsPerFrame int) {
+	cpu.RunFrameUntil(instructionsPerFrame, func() bool { return false })
+}
+
+// RunFrameUntil is RunFrame, but also stops the instant shouldStop reports
+// true after a Step, returning whether that's why it stopped (as opposed to
+// running out of instructions, halting, or DisplayWait). This is what lets
+// a host (e.g. debug.Debugger) interrupt free-running playback the moment
+// the VM lands on a breakpoint, without RunFrame needing to know what a
+// breakpoint is.
+func (cpu *VM) RunFrameUntil(instructionsPerFrame int, shouldStop func() bool) bool {
+	cpu.drewThisFrame = false
+
+	for i := 0; i < instructionsPerFrame && !cpu.Halted; i++ {
+		if cpu.Quirks.DisplayWait && cpu.drewThisFrame {
+			return false
+		}
+		cpu.Step()
+		if shouldStop() {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveKeyWait checks for a key that was down last frame and is up this
+// frame (the release edge), per the standard Fx0A semantics, and stores it
+// in the waiting register.
+func (cpu *VM) resolveKeyWait() {
+	released := cpu.prevKeyboard &^ cpu.KEYBOARD
+	if released == 0 {
+		return
+	}
+
+	for k := uint16(0); k < 16; k++ {
+		if released&(1<<(16-1-k)) != 0 {
+			cpu.V[cpu.waitRegister] = byte(k)
+			cpu.WaitingForKey = false
+			return
+		}
+	}
+}
+
+// Render pushes the current framebuffer to display. Frontends call this
+// once per frame, after driving one or more Step calls.
+func (cpu *VM) Render(display Display) {
+	display.Draw(cpu.FRAMEBUFFER, cpu.Width(), cpu.Height())
+}
+
+// UpdateAudio drives beeper from the sound timer: it starts/stops playback
+// on ST's transitions across zero, and forwards any pattern/pitch change
+// made by F002/Fx3A since the last call. Call this once per frame,
+// alongside TickTimers.
+func (cpu *VM) UpdateAudio(beeper Beeper) {
+	if cpu.patternDirty {
+		beeper.SetPattern(cpu.PatternBuffer, cpu.Pitch)
+		cpu.patternDirty = false
+	}
+
+	if cpu.ST > 0 && !cpu.audioPlaying {
+		beeper.Start()
+		cpu.audioPlaying = true
+	} else if cpu.ST == 0 && cpu.audioPlaying {
+		beeper.Stop()
+		cpu.audioPlaying = false
+	}
+}
@@ -0,0 +1,171 @@
+package chip8
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Quirks captures the ambiguous corners of the CHIP-8 instruction set where
+// real platforms disagree. Different ROMs were written against different
+// interpreters, so the same opcode needs different semantics depending on
+// what the author targeted.
+type Quirks struct {
+	// ShiftUsesVy makes 8xy6/8xyE shift Vy into Vx first (the original COSMAC
+	// VIP behaviour), instead of shifting Vx in place.
+	ShiftUsesVy bool
+	// LoadStoreIncrementsI makes Fx55/Fx65 leave I at I+x+1 after the
+	// transfer, as the VIP did.
+	LoadStoreIncrementsI bool
+	// JumpWithVx makes Bxnn jump to xnn+Vx instead of nnn+V0 (SCHIP).
+	JumpWithVx bool
+	// ClipSprites clips sprites at the screen edge instead of wrapping them
+	// around to the opposite side.
+	ClipSprites bool
+	// LogicResetVF makes 8xy1/8xy2/8xy3 (OR/AND/XOR) reset VF to 0, as the
+	// VIP's AND/OR/XOR opcodes did as a side effect of how they were coded.
+	LogicResetVF bool
+	// DisplayWait makes RunFrame stop for the rest of the 60Hz frame once a
+	// Dxyn has drawn, so at most one sprite draw runs per frame, matching the
+	// VIP's vertical-blank synced draw.
+	DisplayWait bool
+}
+
+// QuirksVIP matches the original COSMAC VIP CHIP-8 interpreter.
+var QuirksVIP = Quirks{
+	ShiftUsesVy:          true,
+	LoadStoreIncrementsI: true,
+	JumpWithVx:           false,
+	ClipSprites:          true,
+	LogicResetVF:         true,
+	DisplayWait:          true,
+}
+
+// QuirksSCHIP matches SCHIP 1.1 on period-correct hardware.
+var QuirksSCHIP = Quirks{
+	ShiftUsesVy:          false,
+	LoadStoreIncrementsI: false,
+	JumpWithVx:           true,
+	ClipSprites:          true,
+	LogicResetVF:         false,
+	DisplayWait:          false,
+}
+
+// QuirksSCHIPModern matches how most modern SCHIP-compatible interpreters
+// behave, which differs from the original SCHIP-1.1 hardware quirks above.
+var QuirksSCHIPModern = Quirks{
+	ShiftUsesVy:          false,
+	LoadStoreIncrementsI: false,
+	JumpWithVx:           true,
+	ClipSprites:          false,
+	LogicResetVF:         false,
+	DisplayWait:          false,
+}
+
+// QuirksXOChip matches the XO-CHIP reference interpreter (Octo).
+var QuirksXOChip = Quirks{
+	ShiftUsesVy:          false,
+	LoadStoreIncrementsI: false,
+	JumpWithVx:           true,
+	ClipSprites:          true,
+	LogicResetVF:         false,
+	DisplayWait:          false,
+}
+
+// QuirksByName resolves one of the named presets ("vip", "schip",
+// "schip-modern", "xochip") so frontends can let a user pick a quirks
+// profile from a CLI flag or config file instead of only selecting it via
+// Variant. The bool is false for an unrecognised name. This only adds that
+// lookup; it resolves into the Quirks struct and presets above, which
+// DecodeExecute/DrawSprite in chip8.go consult.
+func QuirksByName(name string) (Quirks, bool) {
+	switch name {
+	case "vip":
+		return QuirksVIP, true
+	case "schip":
+		return QuirksSCHIP, true
+	case "schip-modern":
+		return QuirksSCHIPModern, true
+	case "xochip":
+		return QuirksXOChip, true
+	default:
+		return Quirks{}, false
+	}
+}
+
+// DefaultQuirks returns the quirks preset a vanilla VM of the given variant
+// is expected to follow.
+func DefaultQuirks(variant Variant) Quirks {
+	switch variant {
+	case SuperChip:
+		return QuirksSCHIPModern
+	case XOChip:
+		return QuirksXOChip
+	default:
+		return QuirksVIP
+	}
+}
+
+// quirksProfile is the on-disk shape of a per-ROM quirks sidecar file.
+type quirksProfile struct {
+	ShiftUsesVy          *bool `json:"shiftUsesVy,omitempty"`
+	LoadStoreIncrementsI *bool `json:"loadStoreIncrementsI,omitempty"`
+	JumpWithVx           *bool `json:"jumpWithVx,omitempty"`
+	ClipSprites          *bool `json:"clipSprites,omitempty"`
+	LogicResetVF         *bool `json:"logicResetVF,omitempty"`
+	DisplayWait          *bool `json:"displayWait,omitempty"`
+}
+
+func (p quirksProfile) applyTo(q Quirks) Quirks {
+	if p.ShiftUsesVy != nil {
+		q.ShiftUsesVy = *p.ShiftUsesVy
+	}
+	if p.LoadStoreIncrementsI != nil {
+		q.LoadStoreIncrementsI = *p.LoadStoreIncrementsI
+	}
+	if p.JumpWithVx != nil {
+		q.JumpWithVx = *p.JumpWithVx
+	}
+	if p.ClipSprites != nil {
+		q.ClipSprites = *p.ClipSprites
+	}
+	if p.LogicResetVF != nil {
+		q.LogicResetVF = *p.LogicResetVF
+	}
+	if p.DisplayWait != nil {
+		q.DisplayWait = *p.DisplayWait
+	}
+	return q
+}
+
+// RomSHA1 returns the hex-encoded SHA-1 digest of a ROM image, used to key
+// per-ROM quirks sidecar files.
+func RomSHA1(rom []byte) string {
+	sum := sha1.Sum(rom)
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadQuirksProfile looks for a "<sha1>.json" sidecar for rom under dir and,
+// if found, overlays it on top of base. Missing fields in the sidecar keep
+// base's value, so a profile only needs to mention what differs. If no
+// sidecar exists, base is returned unchanged.
+func LoadQuirksProfile(dir string, rom []byte, base Quirks) (Quirks, error) {
+	path := filepath.Join(dir, RomSHA1(rom)+".json")
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return base, nil
+	}
+	if err != nil {
+		return base, err
+	}
+
+	var profile quirksProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return base, err
+	}
+
+	return profile.applyTo(base), nil
+}
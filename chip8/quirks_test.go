@@ -0,0 +1,213 @@
+package chip8
+
+import "testing"
+
+func newTestQuirksVM(quirks Quirks) *VM {
+	cpu := &VM{
+		Variant: ChipClassic,
+		Quirks:  quirks,
+		RAM:     make([]byte, ClassicRamSize),
+	}
+	cpu.setResolution(false)
+	cpu.FRAMEBUFFER = make([]byte, cpu.Width()*cpu.Height())
+	return cpu
+}
+
+func TestShiftUsesVyQuirk(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{ShiftUsesVy: true})
+	cpu.V[1] = 0x02 // Vx, should be ignored as the shift source
+	cpu.V[2] = 0x05 // Vy, should be shifted into Vx
+
+	cpu.DecodeExecute(0x8126) // 8xy6: SHR V1 {, V2}
+
+	if cpu.V[1] != 0x02 {
+		t.Fatalf("V1 = %#x, want 0x02 (0x05 >> 1)", cpu.V[1])
+	}
+	if cpu.V[0xF] != 1 {
+		t.Fatalf("VF = %d, want 1 (Vy's low bit)", cpu.V[0xF])
+	}
+}
+
+func TestShiftInPlaceWithoutQuirk(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{ShiftUsesVy: false})
+	cpu.V[1] = 0x03
+	cpu.V[2] = 0xFF
+
+	cpu.DecodeExecute(0x8126) // 8xy6: SHR V1 {, V2}
+
+	if cpu.V[1] != 0x01 {
+		t.Fatalf("V1 = %#x, want 0x01 (0x03 >> 1, Vy ignored)", cpu.V[1])
+	}
+	if cpu.V[0xF] != 1 {
+		t.Fatalf("VF = %d, want 1 (Vx's low bit)", cpu.V[0xF])
+	}
+}
+
+func TestLoadStoreIncrementsIQuirk(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{LoadStoreIncrementsI: true})
+	cpu.I = 0x300
+	cpu.V[0] = 0xAA
+	cpu.V[2] = 0xBB
+
+	cpu.DecodeExecute(0xF255) // Fx55: LD [I], V2
+
+	if cpu.I != 0x303 {
+		t.Fatalf("I = %#x, want 0x303 (0x300 + x+1)", cpu.I)
+	}
+}
+
+func TestLoadStoreLeavesIAloneWithoutQuirk(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{LoadStoreIncrementsI: false})
+	cpu.I = 0x300
+	cpu.V[2] = 0xBB
+
+	cpu.DecodeExecute(0xF255) // Fx55: LD [I], V2
+
+	if cpu.I != 0x300 {
+		t.Fatalf("I = %#x, want unchanged 0x300", cpu.I)
+	}
+}
+
+func TestJumpWithVxQuirk(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{JumpWithVx: true})
+	cpu.V[1] = 0x10
+	cpu.V[0] = 0xFF // should be ignored under this quirk
+
+	cpu.DecodeExecute(0xB100) // Bxnn: JP V1, 0x100 targeting register V1
+
+	if cpu.PC != 0x110 {
+		t.Fatalf("PC = %#x, want 0x110 (0x100 + V1)", cpu.PC)
+	}
+}
+
+func TestJumpWithV0WithoutQuirk(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{JumpWithVx: false})
+	cpu.V[0] = 0x10
+	cpu.V[1] = 0xFF // should be ignored without this quirk
+
+	cpu.DecodeExecute(0xB100) // Bnnn: JP V0, 0x100
+
+	if cpu.PC != 0x110 {
+		t.Fatalf("PC = %#x, want 0x110 (0x100 + V0)", cpu.PC)
+	}
+}
+
+func TestLogicResetVFQuirk(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{LogicResetVF: true})
+	cpu.V[0xF] = 1
+	cpu.V[1] = 0x0F
+	cpu.V[2] = 0xF0
+
+	cpu.DecodeExecute(0x8121) // 8xy1: OR V1, V2
+
+	if cpu.V[0xF] != 0 {
+		t.Fatalf("VF = %d, want 0 (OR/AND/XOR reset it as a side effect)", cpu.V[0xF])
+	}
+}
+
+func TestLogicDoesNotResetVFWithoutQuirk(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{LogicResetVF: false})
+	cpu.V[0xF] = 1
+	cpu.V[1] = 0x0F
+	cpu.V[2] = 0xF0
+
+	cpu.DecodeExecute(0x8121) // 8xy1: OR V1, V2
+
+	if cpu.V[0xF] != 1 {
+		t.Fatalf("VF = %d, want unchanged 1", cpu.V[0xF])
+	}
+}
+
+func TestClipSpritesQuirk(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{ClipSprites: true})
+	cpu.I = 0
+	cpu.RAM[0] = 0xFF // 8 pixels wide, drawn starting one pixel before the right edge
+
+	cpu.DrawSprite(byte(cpu.Width()-1), 0, cpu.RAM[0:1])
+
+	for x := 0; x < cpu.Width(); x++ {
+		if x == cpu.Width()-1 {
+			continue
+		}
+		if cpu.FRAMEBUFFER[x] != 0 {
+			t.Fatalf("FRAMEBUFFER[%d] = %d, want 0 (off-screen columns clipped, not wrapped)", x, cpu.FRAMEBUFFER[x])
+		}
+	}
+}
+
+func TestSpritesWrapWithoutClipQuirk(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{ClipSprites: false})
+	cpu.I = 0
+	cpu.RAM[0] = 0xFF
+
+	cpu.DrawSprite(byte(cpu.Width()-1), 0, cpu.RAM[0:1])
+
+	if cpu.FRAMEBUFFER[0] == 0 {
+		t.Fatal("FRAMEBUFFER[0] = 0, want set (off-screen columns should wrap to x=0)")
+	}
+}
+
+func TestDisplayWaitQuirkStopsRunFrameAfterOneDraw(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{DisplayWait: true})
+	cpu.PC = CHIP8_PROGRAM_START
+	cpu.I = 0x300
+
+	// Two back-to-back Dxyn draws (n=0 is invalid in classic mode, use n=1).
+	base := CHIP8_PROGRAM_START
+	cpu.RAM[base] = 0xD0
+	cpu.RAM[base+1] = 0x01
+	cpu.RAM[base+2] = 0xD0
+	cpu.RAM[base+3] = 0x01
+
+	cpu.RunFrame(10)
+
+	if cpu.PC != uint16(base+2) {
+		t.Fatalf("PC = %#x, want %#x (RunFrame should stop after the first Dxyn under DisplayWait)", cpu.PC, base+2)
+	}
+}
+
+func TestWithoutDisplayWaitRunFrameExecutesBothDraws(t *testing.T) {
+	cpu := newTestQuirksVM(Quirks{DisplayWait: false})
+	cpu.PC = CHIP8_PROGRAM_START
+	cpu.I = 0x300
+
+	base := CHIP8_PROGRAM_START
+	cpu.RAM[base] = 0xD0
+	cpu.RAM[base+1] = 0x01
+	cpu.RAM[base+2] = 0xD0
+	cpu.RAM[base+3] = 0x01
+
+	cpu.RunFrame(2)
+
+	if cpu.PC != uint16(base+4) {
+		t.Fatalf("PC = %#x, want %#x (both Dxyn instructions should run without DisplayWait)", cpu.PC, base+4)
+	}
+}
+
+func TestQuirksByNameKnownPresets(t *testing.T) {
+	cases := []struct {
+		name string
+		want Quirks
+	}{
+		{"vip", QuirksVIP},
+		{"schip", QuirksSCHIP},
+		{"schip-modern", QuirksSCHIPModern},
+		{"xochip", QuirksXOChip},
+	}
+
+	for _, tc := range cases {
+		got, ok := QuirksByName(tc.name)
+		if !ok {
+			t.Fatalf("QuirksByName(%q): ok = false, want true", tc.name)
+		}
+		if got != tc.want {
+			t.Fatalf("QuirksByName(%q) = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestQuirksByNameUnknownPreset(t *testing.T) {
+	if _, ok := QuirksByName("not-a-real-preset"); ok {
+		t.Fatal("QuirksByName(unknown): ok = true, want false")
+	}
+}
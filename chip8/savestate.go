@@ -0,0 +1,129 @@
+package chip8
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+const (
+	saveStateMagic   = "CH8S"
+	saveStateVersion = 1
+
+	saveStateFlagCompressed = 1 << 0
+)
+
+// SaveState serializes the entire VM — registers, memory, framebuffer,
+// keyboard, variant, quirks and SCHIP/XO-CHIP extension state — into a
+// versioned binary blob: a 4-byte "CH8S" magic, a version, a flags byte,
+// the (optionally zlib-compressed) payload, and a trailing CRC32 of
+// everything before it. The payload is gob-encoded so new fields can be
+// added to VM without breaking this format; future SCHIP/XO-CHIP additions
+// should stay backwards compatible for the same reason.
+func (cpu *VM) SaveState(w io.Writer, compress bool) error {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(cpu); err != nil {
+		return fmt.Errorf("chip8: encode save state: %w", err)
+	}
+
+	var body bytes.Buffer
+	body.WriteString(saveStateMagic)
+	binary.Write(&body, binary.LittleEndian, uint16(saveStateVersion))
+
+	var flags byte
+	if compress {
+		flags |= saveStateFlagCompressed
+	}
+	body.WriteByte(flags)
+
+	if compress {
+		zw := zlib.NewWriter(&body)
+		if _, err := zw.Write(payload.Bytes()); err != nil {
+			return fmt.Errorf("chip8: compress save state: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("chip8: compress save state: %w", err)
+		}
+	} else {
+		body.Write(payload.Bytes())
+	}
+
+	if _, err := w.Write(body.Bytes()); err != nil {
+		return err
+	}
+
+	crc := crc32.ChecksumIEEE(body.Bytes())
+	return binary.Write(w, binary.LittleEndian, crc)
+}
+
+// LoadState decodes a save state produced by SaveState into cpu, replacing
+// its current state entirely.
+func (cpu *VM) LoadState(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data) < len(saveStateMagic)+2+1+4 {
+		return fmt.Errorf("chip8: save state is truncated")
+	}
+
+	body, trailer := data[:len(data)-4], data[len(data)-4:]
+	wantCRC := binary.LittleEndian.Uint32(trailer)
+	if gotCRC := crc32.ChecksumIEEE(body); gotCRC != wantCRC {
+		return fmt.Errorf("chip8: save state checksum mismatch (corrupt file?)")
+	}
+
+	if string(body[:len(saveStateMagic)]) != saveStateMagic {
+		return fmt.Errorf("chip8: not a CHIP-8 save state (bad magic)")
+	}
+	body = body[len(saveStateMagic):]
+
+	version := binary.LittleEndian.Uint16(body[:2])
+	if version > saveStateVersion {
+		return fmt.Errorf("chip8: save state version %d is newer than this build supports (%d)", version, saveStateVersion)
+	}
+	body = body[2:]
+
+	flags := body[0]
+	body = body[1:]
+
+	payload := io.Reader(bytes.NewReader(body))
+	if flags&saveStateFlagCompressed != 0 {
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("chip8: decompress save state: %w", err)
+		}
+		defer zr.Close()
+		payload = zr
+	}
+
+	return gob.NewDecoder(payload).Decode(cpu)
+}
+
+// SaveStateFile writes a save state to path, e.g. bound to a hotkey so a
+// host can snapshot mid-game and reload it later.
+func (cpu *VM) SaveStateFile(path string, compress bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return cpu.SaveState(f, compress)
+}
+
+// LoadStateFile reads a save state previously written by SaveStateFile.
+func (cpu *VM) LoadStateFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return cpu.LoadState(f)
+}
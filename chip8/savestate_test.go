@@ -0,0 +1,95 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSaveStateRoundTripsPendingKeyWait(t *testing.T) {
+	cpu := &VM{
+		Variant:     SuperChip,
+		Quirks:      QuirksSCHIPModern,
+		RAM:         make([]byte, ClassicRamSize),
+		FRAMEBUFFER: make([]byte, LoresWidth*LoresHeight),
+		IPF:         30,
+	}
+	cpu.setResolution(false)
+
+	// Simulate a ROM mid-Fx0A: waiting on V3, with key 0x7 already held down
+	// last frame (so only its release, not a fresh press, should resolve it).
+	cpu.DecodeExecute(0xF30A)
+	cpu.KEYBOARD = 1 << (16 - 1 - 0x7)
+	cpu.prevKeyboard = cpu.KEYBOARD
+
+	var buf bytes.Buffer
+	if err := cpu.SaveState(&buf, true); err != nil {
+		t.Fatalf("SaveState: %v", err)
+	}
+
+	restored := &VM{}
+	if err := restored.LoadState(&buf); err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+
+	if !restored.WaitingForKey {
+		t.Fatal("WaitingForKey did not survive the round trip")
+	}
+	if restored.waitRegister != 3 {
+		t.Fatalf("waitRegister = %d, want 3 (restored VM would resolve Fx0A into the wrong register)", restored.waitRegister)
+	}
+	if restored.prevKeyboard != cpu.prevKeyboard {
+		t.Fatalf("prevKeyboard = %#x, want %#x (next release-edge check would misfire)", restored.prevKeyboard, cpu.prevKeyboard)
+	}
+
+	// The key is still held down post-reload: resolveKeyWait must NOT fire
+	// on a key that was already down before the save, only on its release.
+	restored.resolveKeyWait()
+	if !restored.WaitingForKey {
+		t.Fatal("resolveKeyWait fired on a key held steady across the reload, not released")
+	}
+
+	restored.KEYBOARD = 0 // key 0x7 released
+	restored.resolveKeyWait()
+	if restored.WaitingForKey {
+		t.Fatal("resolveKeyWait did not resolve the wait on key release")
+	}
+	if restored.V[3] != 0x7 {
+		t.Fatalf("V[3] = %#x, want 0x7", restored.V[3])
+	}
+}
+
+func TestSaveStateRoundTripsCoreState(t *testing.T) {
+	cpu := &VM{
+		Variant:     XOChip,
+		Quirks:      QuirksXOChip,
+		RAM:         make([]byte, XORamSize),
+		FRAMEBUFFER: make([]byte, LoresWidth*LoresHeight),
+		PC:          0x300,
+		I:           0x400,
+		Planes:      3,
+		Pitch:       40,
+	}
+	cpu.V[0xA] = 0x42
+	cpu.RAM[0x300] = 0xAB
+	cpu.FRAMEBUFFER[5] = 2
+
+	var buf bytes.Buffer
+	if err := cpu.SaveState(&buf, false); err != nil {
+		t.Fatalf("SaveState: %v", err)
+	}
+
+	restored := &VM{}
+	if err := restored.LoadState(&buf); err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+
+	if restored.PC != cpu.PC || restored.I != cpu.I || restored.V[0xA] != cpu.V[0xA] {
+		t.Fatalf("core registers did not round-trip: got PC=%#x I=%#x V[A]=%#x", restored.PC, restored.I, restored.V[0xA])
+	}
+	if restored.Variant != cpu.Variant || restored.Quirks != cpu.Quirks || restored.Planes != cpu.Planes {
+		t.Fatalf("variant/quirks/planes did not round-trip")
+	}
+	if restored.RAM[0x300] != cpu.RAM[0x300] || restored.FRAMEBUFFER[5] != cpu.FRAMEBUFFER[5] {
+		t.Fatalf("RAM/FRAMEBUFFER did not round-trip")
+	}
+}
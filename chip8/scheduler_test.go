@@ -0,0 +1,96 @@
+package chip8
+
+import "testing"
+
+func newTestSchedulerVM() *VM {
+	cpu := &VM{
+		Variant: ChipClassic,
+		Quirks:  QuirksVIP,
+		RAM:     make([]byte, ClassicRamSize),
+		PC:      CHIP8_PROGRAM_START,
+	}
+	cpu.setResolution(false)
+	cpu.FRAMEBUFFER = make([]byte, cpu.Width()*cpu.Height())
+	return cpu
+}
+
+func TestFx0ABlocksStepUntilKeyRelease(t *testing.T) {
+	cpu := newTestSchedulerVM()
+	cpu.RAM[cpu.PC] = 0xF3
+	cpu.RAM[cpu.PC+1] = 0x0A // Fx0A: LD V3, K
+
+	cpu.Step()
+	if !cpu.WaitingForKey {
+		t.Fatal("WaitingForKey = false after fetching Fx0A, want true")
+	}
+	pcAfterFetch := cpu.PC
+
+	// Key pressed but not yet released: Step must not advance past the wait.
+	cpu.UpdateKeyboard([]byte{0x7})
+	cpu.Step()
+	if !cpu.WaitingForKey {
+		t.Fatal("WaitingForKey = false on press alone, want true (only release resolves Fx0A)")
+	}
+	if cpu.PC != pcAfterFetch {
+		t.Fatalf("PC = %#x, want unchanged %#x while still waiting", cpu.PC, pcAfterFetch)
+	}
+
+	// Key released: this Step should resolve the wait and store it in V3.
+	cpu.UpdateKeyboard(nil)
+	cpu.Step()
+	if cpu.WaitingForKey {
+		t.Fatal("WaitingForKey = true after release, want false")
+	}
+	if cpu.V[3] != 0x7 {
+		t.Fatalf("V[3] = %#x, want 0x7", cpu.V[3])
+	}
+}
+
+func TestRunFrameStopsInstructionCountOnWait(t *testing.T) {
+	cpu := newTestSchedulerVM()
+	cpu.RAM[cpu.PC] = 0xF3
+	cpu.RAM[cpu.PC+1] = 0x0A // Fx0A: LD V3, K
+
+	// A full frame of Steps while waiting should not busy-spin PC or panic;
+	// it should just keep polling the same instruction.
+	cpu.RunFrame(10)
+	if !cpu.WaitingForKey {
+		t.Fatal("WaitingForKey = false after a frame of polling, want still true (no key released)")
+	}
+}
+
+func TestRunFrameUntilReturnsTrueWhenShouldStopFires(t *testing.T) {
+	cpu := newTestSchedulerVM()
+	cpu.RAM[cpu.PC] = 0x60
+	cpu.RAM[cpu.PC+1] = 0x01 // 6xkk: LD V0, 0x01
+	cpu.RAM[cpu.PC+2] = 0x60
+	cpu.RAM[cpu.PC+3] = 0x02 // 6xkk: LD V0, 0x02
+
+	calls := 0
+	stopped := cpu.RunFrameUntil(10, func() bool {
+		calls++
+		return calls == 1
+	})
+
+	if !stopped {
+		t.Fatal("RunFrameUntil returned false, want true (shouldStop fired)")
+	}
+	if cpu.V[0] != 0x01 {
+		t.Fatalf("V[0] = %#x, want 0x01 (only the first instruction should have run)", cpu.V[0])
+	}
+}
+
+func TestRunFrameUntilReturnsFalseWhenShouldStopNeverFires(t *testing.T) {
+	cpu := newTestSchedulerVM()
+	cpu.RAM[cpu.PC] = 0x60
+	cpu.RAM[cpu.PC+1] = 0x01 // 6xkk: LD V0, 0x01
+
+	stopped := cpu.RunFrameUntil(1, func() bool { return false })
+
+	if stopped {
+		t.Fatal("RunFrameUntil returned true, want false (shouldStop never fired)")
+	}
+	if cpu.V[0] != 0x01 {
+		t.Fatalf("V[0] = %#x, want 0x01", cpu.V[0])
+	}
+}
@@ -0,0 +1,398 @@
+package chip8
+
+import "math"
+
+// PatternPlaybackRate converts an XO-CHIP Fx3A pitch value into the sample
+// rate (Hz) the 1-bit audio pattern buffer should be played back at.
+func PatternPlaybackRate(pitch byte) float64 {
+	return 4000 * math.Pow(2, (float64(pitch)-64)/48)
+}
+
+// Variant selects which instruction set and quirks the VM emulates.
+type Variant int
+
+const (
+	// ChipClassic is the original COSMAC VIP CHIP-8 instruction set.
+	ChipClassic Variant = iota
+	// SuperChip adds the SCHIP 1.1 128x64 hi-res mode and extended opcodes.
+	SuperChip
+	// XOChip layers the XO-CHIP superset on top of SuperChip: colour planes,
+	// the audio pattern buffer, 16-bit long addressing and a 64KB address space.
+	XOChip
+)
+
+func (v Variant) String() string {
+	switch v {
+	case SuperChip:
+		return "SCHIP"
+	case XOChip:
+		return "XO-CHIP"
+	default:
+		return "CHIP-8"
+	}
+}
+
+const (
+	LoresWidth  = FB_WIDTH
+	LoresHeight = FB_HEIGHT
+	HiresWidth  = LoresWidth * 2
+	HiresHeight = LoresHeight * 2
+
+	// ClassicRamSize is the 4KB address space of the original CHIP-8/SCHIP.
+	ClassicRamSize = 4096
+	// XORamSize is the 64KB address space XO-CHIP programs may address.
+	XORamSize = 65536
+
+	BigDigitsLen = 10
+	// BigDigitsOffset is where the 8x10 SCHIP font is loaded, just after
+	// the 16 small 5-byte digit glyphs at the bottom of RAM.
+	BigDigitsOffset = DIGITS_LEN * 16
+)
+
+// BIG_DIGITS is the 8x10 hi-res font used by Fx30, one 10-byte glyph per
+// digit 0-9 as shipped by SCHIP 1.1.
+var BIG_DIGITS = [...]byte{
+	0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C, // 0
+	0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C, // 1
+	0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF, // 2
+	0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C, // 3
+	0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06, // 4
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C, // 5
+	0x3E, 0x7C, 0xC0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C, // 6
+	0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x30, 0x30, 0x30, // 7
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C, // 8
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0xC3, 0x7E, 0x3C, // 9
+}
+
+// DefaultIPF returns the instructions-per-frame a vanilla VM of the given
+// variant runs at. SCHIP and XO-CHIP ROMs are generally authored assuming a
+// much faster interpreter than the original ~540-1000Hz COSMAC VIP.
+func DefaultIPF(v Variant) int {
+	switch v {
+	case SuperChip:
+		return 30
+	case XOChip:
+		return 1000
+	default:
+		return 11
+	}
+}
+
+// Width returns the active framebuffer width for the VM's current resolution mode.
+func (cpu *VM) Width() int {
+	if cpu.Hires {
+		return HiresWidth
+	}
+	return LoresWidth
+}
+
+// Height returns the active framebuffer height for the VM's current resolution mode.
+func (cpu *VM) Height() int {
+	if cpu.Hires {
+		return HiresHeight
+	}
+	return LoresHeight
+}
+
+// setResolution switches the active display mode, resizing and clearing the framebuffer.
+func (cpu *VM) setResolution(hires bool) {
+	cpu.Hires = hires
+	cpu.FRAMEBUFFER = make([]byte, cpu.Width()*cpu.Height())
+}
+
+// scrollDown moves every pixel (all planes, regardless of Planes) down n rows, per the SCHIP 00Cn opcode.
+func (cpu *VM) scrollDown(n int) {
+	w, h := cpu.Width(), cpu.Height()
+	for y := h - 1; y >= 0; y-- {
+		for x := 0; x < w; x++ {
+			src := y - n
+			if src >= 0 {
+				cpu.FRAMEBUFFER[y*w+x] = cpu.FRAMEBUFFER[src*w+x]
+			} else {
+				cpu.FRAMEBUFFER[y*w+x] = 0
+			}
+		}
+	}
+}
+
+// scrollRight moves every pixel 4 columns right (00FB).
+func (cpu *VM) scrollRight() {
+	w, h := cpu.Width(), cpu.Height()
+	for y := 0; y < h; y++ {
+		for x := w - 1; x >= 0; x-- {
+			src := x - 4
+			if src >= 0 {
+				cpu.FRAMEBUFFER[y*w+x] = cpu.FRAMEBUFFER[y*w+src]
+			} else {
+				cpu.FRAMEBUFFER[y*w+x] = 0
+			}
+		}
+	}
+}
+
+// scrollLeft moves every pixel 4 columns left (00FC).
+func (cpu *VM) scrollLeft() {
+	w, h := cpu.Width(), cpu.Height()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			src := x + 4
+			if src < w {
+				cpu.FRAMEBUFFER[y*w+x] = cpu.FRAMEBUFFER[y*w+src]
+			} else {
+				cpu.FRAMEBUFFER[y*w+x] = 0
+			}
+		}
+	}
+}
+
+// drawSpriteExtended draws a standard n-byte sprite or, when n==0 in hi-res
+// mode, a 16x16 SCHIP sprite. It honours ClipSprites and reports
+// per-row collision the way SCHIP/XO-CHIP expect.
+func (cpu *VM) drawSpriteExtended(vx, vy byte, n uint16) {
+	w, h := cpu.Width(), cpu.Height()
+
+	if n == 0 && cpu.Hires {
+		cpu.drawSprite16x16(vx, vy, w, h)
+		return
+	}
+
+	cpu.V[0xF] = 0
+	rowBytes := cpu.RAM[cpu.I : cpu.I+n]
+
+	for dy, v := range rowBytes {
+		rowCollision := false
+		for index := byte(0); index < 8; index++ {
+			dx := 7 - index
+			pixel := ((v >> dx) & 1) > 0
+			if !pixel {
+				continue
+			}
+
+			x := int(vx) + int(index)
+			y := int(vy) + dy
+
+			if cpu.Quirks.ClipSprites {
+				if x >= w || y >= h {
+					continue
+				}
+			} else {
+				x %= w
+				y %= h
+			}
+
+			loc := y*w + x
+			if cpu.FRAMEBUFFER[loc] != 0 {
+				rowCollision = true
+			}
+			cpu.FRAMEBUFFER[loc] ^= 1
+		}
+		if rowCollision {
+			cpu.V[0xF] = 1
+		}
+	}
+}
+
+func (cpu *VM) drawSprite16x16(vx, vy byte, w, h int) {
+	cpu.V[0xF] = 0
+	sprite := cpu.RAM[cpu.I : cpu.I+32]
+
+	for dy := 0; dy < 16; dy++ {
+		rowCollision := false
+		row := uint16(sprite[dy*2])<<8 | uint16(sprite[dy*2+1])
+
+		for index := 0; index < 16; index++ {
+			dx := 15 - index
+			pixel := ((row >> dx) & 1) > 0
+			if !pixel {
+				continue
+			}
+
+			x := int(vx) + index
+			y := int(vy) + dy
+
+			if cpu.Quirks.ClipSprites {
+				if x >= w || y >= h {
+					continue
+				}
+			} else {
+				x %= w
+				y %= h
+			}
+
+			loc := y*w + x
+			if cpu.FRAMEBUFFER[loc] != 0 {
+				rowCollision = true
+			}
+			cpu.FRAMEBUFFER[loc] ^= 1
+		}
+		if rowCollision {
+			cpu.V[0xF] = 1
+		}
+	}
+}
+
+// clearPlanes clears only the bit(s) of FRAMEBUFFER selected by cpu.Planes
+// (00E0), matching Octo's XO-CHIP behaviour where a prior Fx01 narrows what
+// a clear erases. Non-XO-CHIP variants never change Planes from its
+// Initialize default of 1, so this still clears the whole screen for them.
+func (cpu *VM) clearPlanes() {
+	mask := ^cpu.Planes
+	for i, v := range cpu.FRAMEBUFFER {
+		cpu.FRAMEBUFFER[i] = v & mask
+	}
+}
+
+// drawSpriteXOChip draws a sprite into the plane(s) selected by Fx01
+// (cpu.Planes), XORing each selected plane's bit independently into
+// FRAMEBUFFER instead of the flat on/off bit DrawSprite/drawSpriteExtended
+// use. Planes==0 draws nothing and reports no collision, as Octo does. As
+// with SCHIP, n==0 in hi-res mode draws a 16x16 sprite instead of 8xN; when
+// both planes are selected, plane 1's sprite data immediately follows plane
+// 0's in memory.
+func (cpu *VM) drawSpriteXOChip(vx, vy byte, n uint16) {
+	cpu.V[0xF] = 0
+	if cpu.Planes == 0 {
+		return
+	}
+
+	rows, width := int(n), 8
+	if n == 0 {
+		rows, width = 16, 16
+	}
+	bytesPerRow := width / 8
+
+	w, h := cpu.Width(), cpu.Height()
+	addr := cpu.I
+	collided := false
+
+	for plane := byte(0); plane < 2; plane++ {
+		bit := byte(1) << plane
+		if cpu.Planes&bit == 0 {
+			continue
+		}
+		if cpu.drawPlane(vx, vy, addr, rows, width, bit, w, h) {
+			collided = true
+		}
+		addr += uint16(rows * bytesPerRow)
+	}
+
+	if collided {
+		cpu.V[0xF] = 1
+	}
+}
+
+// drawPlane XORs a single rows x width sprite at addr into the bit of
+// FRAMEBUFFER selected by bit (1<<plane), honouring ClipSprites the same
+// way the other sprite drawers do, and reports whether any pixel on that
+// plane was erased.
+func (cpu *VM) drawPlane(vx, vy byte, addr uint16, rows, width int, bit byte, w, h int) bool {
+	bytesPerRow := width / 8
+	collision := false
+
+	for dy := 0; dy < rows; dy++ {
+		for dx := 0; dx < width; dx++ {
+			rowByte := cpu.RAM[addr+uint16(dy*bytesPerRow)+uint16(dx/8)]
+			shift := byte(7 - dx%8)
+			if (rowByte>>shift)&1 == 0 {
+				continue
+			}
+
+			x := int(vx) + dx
+			y := int(vy) + dy
+
+			if cpu.Quirks.ClipSprites {
+				if x >= w || y >= h {
+					continue
+				}
+			} else {
+				x %= w
+				y %= h
+			}
+
+			loc := y*w + x
+			if cpu.FRAMEBUFFER[loc]&bit != 0 {
+				collision = true
+			}
+			cpu.FRAMEBUFFER[loc] ^= bit
+		}
+	}
+
+	return collision
+}
+
+// decodeExtended handles the SCHIP/XO-CHIP opcodes that have no classic
+// CHIP-8 meaning. It returns true if it recognised and executed the
+// instruction.
+func (cpu *VM) decodeExtended(instruction uint16) bool {
+	if cpu.Variant == ChipClassic {
+		return false
+	}
+
+	x := instruction & 0x0F00 >> 8
+
+	switch {
+	case instruction&0xFFF0 == 0x00C0: // 00Cn - SCD n
+		cpu.scrollDown(int(instruction & 0x000F))
+	case instruction == 0x00FB: // SCR
+		cpu.scrollRight()
+	case instruction == 0x00FC: // SCL
+		cpu.scrollLeft()
+	case instruction == 0x00FE: // LORES
+		cpu.setResolution(false)
+	case instruction == 0x00FF: // HIRES
+		cpu.setResolution(true)
+	case instruction == 0x00FD: // EXIT
+		cpu.Halted = true
+	case instruction&0xF0FF == 0xF030: // Fx30 - LD HF, Vx
+		cpu.I = BigDigitsOffset + uint16(cpu.V[x])*BigDigitsLen
+	case instruction&0xF0FF == 0xF075: // Fx75 - LD R, Vx
+		copy(cpu.RPL[:x+1], cpu.V[:x+1])
+	case instruction&0xF0FF == 0xF085: // Fx85 - LD Vx, R
+		copy(cpu.V[:x+1], cpu.RPL[:x+1])
+	default:
+		return cpu.decodeXOChip(instruction)
+	}
+
+	return true
+}
+
+func (cpu *VM) decodeXOChip(instruction uint16) bool {
+	if cpu.Variant != XOChip {
+		return false
+	}
+
+	x := instruction & 0x0F00 >> 8
+
+	switch {
+	case instruction&0xF0FF == 0xF001: // Fx01 - select drawing plane(s) x
+		cpu.Planes = byte(x) & 0x3
+	case instruction&0xFFF0 == 0x00D0: // 00Dn - SCU n, scroll up n lines
+		w, h := cpu.Width(), cpu.Height()
+		n := int(instruction & 0x000F)
+		for y := 0; y < h; y++ {
+			for xi := 0; xi < w; xi++ {
+				src := y + n
+				if src < h {
+					cpu.FRAMEBUFFER[y*w+xi] = cpu.FRAMEBUFFER[src*w+xi]
+				} else {
+					cpu.FRAMEBUFFER[y*w+xi] = 0
+				}
+			}
+		}
+	case instruction == 0xF000: // F000 NNNN - LD I, long
+		hi := uint16(cpu.RAM[cpu.PC])
+		lo := uint16(cpu.RAM[cpu.PC+1])
+		cpu.I = hi<<8 | lo
+		cpu.PC += 2
+	case instruction == 0xF002: // F002 - load 16-byte audio pattern from RAM at I
+		copy(cpu.PatternBuffer[:], cpu.RAM[cpu.I:cpu.I+16])
+		cpu.patternDirty = true
+	case instruction&0xF0FF == 0xF03A: // Fx3A - PITCH Vx
+		cpu.Pitch = cpu.V[x]
+		cpu.patternDirty = true
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,86 @@
+package chip8
+
+import "testing"
+
+func newTestXOChipVM() *VM {
+	return &VM{
+		Variant: XOChip,
+		Quirks:  QuirksXOChip,
+		RAM:     make([]byte, 256),
+		Hires:   false,
+	}
+}
+
+func TestDrawSpriteXOChipPlaneSelection(t *testing.T) {
+	cases := []struct {
+		name      string
+		planes    byte
+		wantPixel byte
+		wantVF    byte
+	}{
+		{"no planes selected draws nothing", 0, 0, 0},
+		{"plane 0 only", 1, 1, 0},
+		{"plane 1 only", 2, 2, 0},
+		{"both planes", 3, 3, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cpu := newTestXOChipVM()
+			cpu.FRAMEBUFFER = make([]byte, cpu.Width()*cpu.Height())
+			cpu.Planes = tc.planes
+			cpu.I = 0
+			// Plane 0 sprite byte, then plane 1 sprite byte right after it.
+			cpu.RAM[0] = 0x80 // top-left pixel set
+			cpu.RAM[1] = 0x80
+
+			cpu.drawSpriteXOChip(0, 0, 1)
+
+			got := cpu.FRAMEBUFFER[0]
+			if got != tc.wantPixel {
+				t.Errorf("FRAMEBUFFER[0] = %#x, want %#x", got, tc.wantPixel)
+			}
+			if cpu.V[0xF] != tc.wantVF {
+				t.Errorf("VF = %d, want %d", cpu.V[0xF], tc.wantVF)
+			}
+		})
+	}
+}
+
+func TestDrawSpriteXOChipCollisionPerPlane(t *testing.T) {
+	cpu := newTestXOChipVM()
+	cpu.FRAMEBUFFER = make([]byte, cpu.Width()*cpu.Height())
+	cpu.Planes = 1
+	cpu.I = 0
+	cpu.RAM[0] = 0x80
+
+	cpu.drawSpriteXOChip(0, 0, 1) // sets bit0
+	if cpu.FRAMEBUFFER[0] != 1 || cpu.V[0xF] != 0 {
+		t.Fatalf("after first draw: FRAMEBUFFER[0]=%#x VF=%d", cpu.FRAMEBUFFER[0], cpu.V[0xF])
+	}
+
+	cpu.drawSpriteXOChip(0, 0, 1) // XORs bit0 off again, should collide
+	if cpu.FRAMEBUFFER[0] != 0 {
+		t.Fatalf("after second draw: FRAMEBUFFER[0]=%#x, want 0", cpu.FRAMEBUFFER[0])
+	}
+	if cpu.V[0xF] != 1 {
+		t.Fatalf("VF = %d after erasing plane 0 pixel, want 1", cpu.V[0xF])
+	}
+}
+
+func TestClearPlanesOnlyClearsSelectedPlane(t *testing.T) {
+	cpu := newTestXOChipVM()
+	cpu.FRAMEBUFFER = make([]byte, cpu.Width()*cpu.Height())
+	for i := range cpu.FRAMEBUFFER {
+		cpu.FRAMEBUFFER[i] = 3 // both planes set
+	}
+
+	cpu.Planes = 1 // only plane 0 selected
+	cpu.clearPlanes()
+
+	for i, v := range cpu.FRAMEBUFFER {
+		if v != 2 {
+			t.Fatalf("FRAMEBUFFER[%d] = %#x, want 0x2 (plane 1 bit preserved)", i, v)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package debug
+
+import "github.com/andrepcg/chip-8-emu/chip8"
+
+// Watchpoint fires when the watched V register or RAM range changes value
+// between one Step and the next.
+type Watchpoint struct {
+	Label    string
+	Register int // 0-15, or -1 to watch a RAM range instead
+	RAMStart uint16
+	RAMEnd   uint16 // exclusive; ignored when Register >= 0
+}
+
+// Debugger wraps a chip8.VM with breakpoints, watchpoints, single/step-over
+// execution, lifecycle hooks and a rewind ring, without the VM itself
+// needing to know it is being debugged.
+type Debugger struct {
+	VM          *chip8.VM
+	Breakpoints map[uint16]bool
+	Watchpoints []Watchpoint
+
+	// BeforeStep and AfterStep, if set, are called around every instruction
+	// StepInto executes, so a UI can refresh its view of V/stack/I/PC/
+	// timers/keyboard/framebuffer without polling.
+	BeforeStep func(vm *chip8.VM)
+	AfterStep  func(vm *chip8.VM, triggered []Watchpoint)
+
+	rewind      *Ring
+	watchValues map[int]byte
+	watchRAM    map[int]string
+}
+
+// New wraps vm for debugging, keeping the last ringSize Checkpoint/StepInto
+// snapshots available for Rewind. ringSize <= 0 disables rewind.
+func New(vm *chip8.VM, ringSize int) *Debugger {
+	d := &Debugger{
+		VM:          vm,
+		Breakpoints: make(map[uint16]bool),
+		watchValues: make(map[int]byte),
+		watchRAM:    make(map[int]string),
+	}
+	if ringSize > 0 {
+		d.rewind = NewRing(ringSize)
+	}
+	return d
+}
+
+// WatchRegister triggers label whenever V[register] changes.
+func (d *Debugger) WatchRegister(label string, register int) {
+	d.Watchpoints = append(d.Watchpoints, Watchpoint{Label: label, Register: register})
+}
+
+// WatchRAM triggers label whenever any byte in RAM[start:end] changes.
+func (d *Debugger) WatchRAM(label string, start, end uint16) {
+	d.Watchpoints = append(d.Watchpoints, Watchpoint{Label: label, Register: -1, RAMStart: start, RAMEnd: end})
+}
+
+// checkWatchpoints compares the current VM state against the values
+// recorded before the last step and returns the watchpoints that changed.
+func (d *Debugger) checkWatchpoints() []Watchpoint {
+	var triggered []Watchpoint
+
+	for i, w := range d.Watchpoints {
+		if w.Register >= 0 {
+			cur := d.VM.V[w.Register]
+			if prev, ok := d.watchValues[i]; !ok || prev != cur {
+				d.watchValues[i] = cur
+				if ok {
+					triggered = append(triggered, w)
+				}
+			}
+			continue
+		}
+
+		cur := string(d.VM.RAM[w.RAMStart:w.RAMEnd])
+		if prev, ok := d.watchRAM[i]; !ok || prev != cur {
+			d.watchRAM[i] = cur
+			if ok {
+				triggered = append(triggered, w)
+			}
+		}
+	}
+
+	return triggered
+}
+
+func (d *Debugger) AddBreakpoint(pc uint16) {
+	d.Breakpoints[pc] = true
+}
+
+func (d *Debugger) RemoveBreakpoint(pc uint16) {
+	delete(d.Breakpoints, pc)
+}
+
+// AtBreakpoint reports whether the VM's current PC has a breakpoint set.
+func (d *Debugger) AtBreakpoint() bool {
+	return d.Breakpoints[d.VM.PC]
+}
+
+// snapshotIfTracked records a rewind point before executing, if rewind is enabled.
+func (d *Debugger) snapshotIfTracked() {
+	if d.rewind != nil {
+		d.rewind.Push(Snap(d.VM))
+	}
+}
+
+// Checkpoint records a rewind point for the VM's current state. Hosts that
+// drive the VM a frame (RunFrame) at a time, rather than one StepInto at a
+// time, should call this once per frame instead of relying on StepInto's
+// implicit checkpoints.
+func (d *Debugger) Checkpoint() {
+	d.snapshotIfTracked()
+}
+
+// RunFrame executes up to instructionsPerFrame instructions exactly like
+// cpu.RunFrame, but stops the instant the VM's PC lands on a breakpoint
+// instead of running the rest of the frame through it blind. Hosts that want
+// `B` breakpoints to actually interrupt free-running playback should call
+// this instead of cpu.RunFrame, and stop auto-running frames once it
+// returns true until the user steps or explicitly continues. Call
+// Checkpoint once per frame alongside this, the same as with cpu.RunFrame.
+func (d *Debugger) RunFrame(instructionsPerFrame int) bool {
+	return d.VM.RunFrameUntil(instructionsPerFrame, d.AtBreakpoint)
+}
+
+// StepInto executes exactly one instruction, recording a rewind snapshot
+// beforehand, and firing BeforeStep/AfterStep and any triggered watchpoints
+// around it.
+func (d *Debugger) StepInto() {
+	d.snapshotIfTracked()
+
+	if d.BeforeStep != nil {
+		d.BeforeStep(d.VM)
+	}
+
+	d.VM.Step()
+
+	triggered := d.checkWatchpoints()
+	if d.AfterStep != nil {
+		d.AfterStep(d.VM, triggered)
+	}
+}
+
+// StepOver executes one instruction, but if it is a CALL (2nnn), runs until
+// control returns to the instruction right after it (or a breakpoint is
+// hit, the VM halts, or the subroutine blocks on Fx0A), instead of dropping
+// into the subroutine. Without the WaitingForKey check, a subroutine that
+// waits on a keypress that never comes would spin StepInto forever with no
+// way back to the caller — exactly the busy-wait the debugger exists to
+// avoid.
+func (d *Debugger) StepOver() {
+	pc := d.VM.PC
+	instruction := uint16(d.VM.RAM[pc])<<8 | uint16(d.VM.RAM[pc+1])
+
+	if instruction&0xF000 != 0x2000 {
+		d.StepInto()
+		return
+	}
+
+	target := pc + 2
+	d.StepInto()
+	for d.VM.PC != target && !d.VM.Halted && !d.AtBreakpoint() && !d.VM.WaitingForKey {
+		d.StepInto()
+	}
+}
+
+// Rewind restores the VM to the state it was in `steps` snapshots ago, where
+// each StepInto and each Checkpoint records one snapshot. It reports false if
+// there isn't enough history.
+func (d *Debugger) Rewind(steps int) bool {
+	if d.rewind == nil {
+		return false
+	}
+	snap := d.rewind.Back(steps)
+	if snap == nil {
+		return false
+	}
+	snap.Restore(d.VM)
+	return true
+}
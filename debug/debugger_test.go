@@ -0,0 +1,124 @@
+package debug
+
+import (
+	"testing"
+
+	"github.com/andrepcg/chip-8-emu/chip8"
+)
+
+func newTestVM() *chip8.VM {
+	cpu := &chip8.VM{
+		Variant: chip8.ChipClassic,
+		Quirks:  chip8.QuirksVIP,
+		RAM:     make([]byte, chip8.ClassicRamSize),
+		PC:      chip8.CHIP8_PROGRAM_START,
+	}
+	cpu.FRAMEBUFFER = make([]byte, chip8.LoresWidth*chip8.LoresHeight)
+	return cpu
+}
+
+func TestAddRemoveBreakpoint(t *testing.T) {
+	vm := newTestVM()
+	d := New(vm, 0)
+
+	if d.AtBreakpoint() {
+		t.Fatal("AtBreakpoint() = true before any breakpoint is set")
+	}
+
+	d.AddBreakpoint(vm.PC)
+	if !d.AtBreakpoint() {
+		t.Fatal("AtBreakpoint() = false right after AddBreakpoint on the current PC")
+	}
+
+	d.RemoveBreakpoint(vm.PC)
+	if d.AtBreakpoint() {
+		t.Fatal("AtBreakpoint() = true after RemoveBreakpoint")
+	}
+}
+
+func TestRunFrameStopsAtBreakpoint(t *testing.T) {
+	vm := newTestVM()
+	base := int(vm.PC)
+	vm.RAM[base] = 0x60
+	vm.RAM[base+1] = 0x01 // 6xkk: LD V0, 0x01
+	vm.RAM[base+2] = 0x60
+	vm.RAM[base+3] = 0x02 // 6xkk: LD V0, 0x02
+
+	d := New(vm, 0)
+	d.AddBreakpoint(uint16(base + 2))
+
+	stopped := d.RunFrame(10)
+
+	if !stopped {
+		t.Fatal("RunFrame returned false, want true (a breakpoint sits at the second instruction)")
+	}
+	if vm.PC != uint16(base+2) {
+		t.Fatalf("PC = %#x, want %#x (should stop right at the breakpoint)", vm.PC, base+2)
+	}
+	if vm.V[0] != 0x01 {
+		t.Fatalf("V[0] = %#x, want 0x01 (only the first instruction should have run)", vm.V[0])
+	}
+}
+
+func TestWatchRegisterFiresOnlyOnChange(t *testing.T) {
+	vm := newTestVM()
+	base := int(vm.PC)
+	vm.RAM[base] = 0x60
+	vm.RAM[base+1] = 0x01 // LD V0, 0x01 (changes V0)
+	vm.RAM[base+2] = 0x61
+	vm.RAM[base+3] = 0x05 // LD V1, 0x05 (does not touch V0)
+
+	d := New(vm, 0)
+	d.WatchRegister("v0", 0)
+
+	var triggeredCounts []int
+	d.AfterStep = func(_ *chip8.VM, triggered []Watchpoint) {
+		triggeredCounts = append(triggeredCounts, len(triggered))
+	}
+
+	d.StepInto() // first sample of V0, no prior value to compare against
+	d.StepInto() // V0 unchanged by this instruction
+
+	if triggeredCounts[0] != 0 {
+		t.Fatalf("triggered count on first step = %d, want 0 (nothing to compare against yet)", triggeredCounts[0])
+	}
+	if triggeredCounts[1] != 0 {
+		t.Fatalf("triggered count on second step = %d, want 0 (V0 didn't change)", triggeredCounts[1])
+	}
+}
+
+func TestStepOverRunsThroughCallWithoutDroppingIn(t *testing.T) {
+	vm := newTestVM()
+	base := int(vm.PC)
+	vm.RAM[base] = 0x23
+	vm.RAM[base+1] = 0x00 // 2nnn: CALL 0x300
+	vm.RAM[base+2] = 0x60
+	vm.RAM[base+3] = 0x99 // LD V0, 0x99 (the instruction after the call)
+
+	vm.RAM[0x300] = 0x00
+	vm.RAM[0x301] = 0xEE // RET
+
+	d := New(vm, 0)
+	d.StepOver()
+
+	if vm.PC != uint16(base+2) {
+		t.Fatalf("PC = %#x, want %#x (StepOver should land right after the CALL)", vm.PC, base+2)
+	}
+}
+
+func TestStepOverStopsInsteadOfHangingOnFx0A(t *testing.T) {
+	vm := newTestVM()
+	base := int(vm.PC)
+	vm.RAM[base] = 0x23
+	vm.RAM[base+1] = 0x00 // CALL 0x300
+
+	vm.RAM[0x300] = 0xF0
+	vm.RAM[0x301] = 0x0A // Fx0A: LD V0, K (blocks forever with no key press)
+
+	d := New(vm, 0)
+	d.StepOver()
+
+	if !vm.WaitingForKey {
+		t.Fatal("WaitingForKey = false, want true (StepOver should stop at the Fx0A wait, not spin forever)")
+	}
+}
@@ -0,0 +1,122 @@
+// Package debug provides a disassembler, breakpoint/step debugger and
+// snapshot-based rewind on top of a chip8.VM, for tooling that wants to
+// inspect or control emulation rather than just run it.
+package debug
+
+import "fmt"
+
+// Disassemble returns the mnemonic for a single fetched instruction,
+// covering the classic CHIP-8 set plus the SCHIP/XO-CHIP extensions.
+// Unrecognised instructions are rendered as "DW 0xNNNN", mirroring how
+// assemblers print raw data words they can't decode.
+func Disassemble(instruction uint16) string {
+	x := instruction & 0x0F00 >> 8
+	y := instruction & 0x00F0 >> 4
+	n := instruction & 0x000F
+	kk := instruction & 0x00FF
+	nnn := instruction & 0x0FFF
+
+	switch instruction {
+	case 0x00E0:
+		return "CLS"
+	case 0x00EE:
+		return "RET"
+	case 0x00FB:
+		return "SCR"
+	case 0x00FC:
+		return "SCL"
+	case 0x00FD:
+		return "EXIT"
+	case 0x00FE:
+		return "LOW"
+	case 0x00FF:
+		return "HIGH"
+	case 0xF000:
+		return "LD I, long"
+	case 0xF002:
+		return "PLANE-PATTERN"
+	}
+
+	switch {
+	case instruction&0xFFF0 == 0x00C0:
+		return fmt.Sprintf("SCD %d", n)
+	case instruction&0xFFF0 == 0x00D0:
+		return fmt.Sprintf("SCU %d", n)
+	case instruction&0xF000 == 0x1000:
+		return fmt.Sprintf("JP 0x%03X", nnn)
+	case instruction&0xF000 == 0x2000:
+		return fmt.Sprintf("CALL 0x%03X", nnn)
+	case instruction&0xF000 == 0x3000:
+		return fmt.Sprintf("SE V%X, 0x%02X", x, kk)
+	case instruction&0xF000 == 0x4000:
+		return fmt.Sprintf("SNE V%X, 0x%02X", x, kk)
+	case instruction&0xF00F == 0x5000:
+		return fmt.Sprintf("SE V%X, V%X", x, y)
+	case instruction&0xF000 == 0x6000:
+		return fmt.Sprintf("LD V%X, 0x%02X", x, kk)
+	case instruction&0xF000 == 0x7000:
+		return fmt.Sprintf("ADD V%X, 0x%02X", x, kk)
+	case instruction&0xF00F == 0x8000:
+		return fmt.Sprintf("LD V%X, V%X", x, y)
+	case instruction&0xF00F == 0x8001:
+		return fmt.Sprintf("OR V%X, V%X", x, y)
+	case instruction&0xF00F == 0x8002:
+		return fmt.Sprintf("AND V%X, V%X", x, y)
+	case instruction&0xF00F == 0x8003:
+		return fmt.Sprintf("XOR V%X, V%X", x, y)
+	case instruction&0xF00F == 0x8004:
+		return fmt.Sprintf("ADD V%X, V%X", x, y)
+	case instruction&0xF00F == 0x8005:
+		return fmt.Sprintf("SUB V%X, V%X", x, y)
+	case instruction&0xF00F == 0x8006:
+		return fmt.Sprintf("SHR V%X {, V%X}", x, y)
+	case instruction&0xF00F == 0x8007:
+		return fmt.Sprintf("SUBN V%X, V%X", x, y)
+	case instruction&0xF00F == 0x800E:
+		return fmt.Sprintf("SHL V%X {, V%X}", x, y)
+	case instruction&0xF00F == 0x9000:
+		return fmt.Sprintf("SNE V%X, V%X", x, y)
+	case instruction&0xF000 == 0xA000:
+		return fmt.Sprintf("LD I, 0x%03X", nnn)
+	case instruction&0xF000 == 0xB000:
+		return fmt.Sprintf("JP V0, 0x%03X", nnn)
+	case instruction&0xF000 == 0xC000:
+		return fmt.Sprintf("RND V%X, 0x%02X", x, kk)
+	case instruction&0xF000 == 0xD000:
+		return fmt.Sprintf("DRW V%X, V%X, %d", x, y, n)
+	case instruction&0xF0FF == 0xE09E:
+		return fmt.Sprintf("SKP V%X", x)
+	case instruction&0xF0FF == 0xE0A1:
+		return fmt.Sprintf("SKNP V%X", x)
+	case instruction&0xF0FF == 0xF001:
+		return fmt.Sprintf("PLANE %d", x)
+	case instruction&0xF0FF == 0xF007:
+		return fmt.Sprintf("LD V%X, DT", x)
+	case instruction&0xF0FF == 0xF00A:
+		return fmt.Sprintf("LD V%X, K", x)
+	case instruction&0xF0FF == 0xF015:
+		return fmt.Sprintf("LD DT, V%X", x)
+	case instruction&0xF0FF == 0xF018:
+		return fmt.Sprintf("LD ST, V%X", x)
+	case instruction&0xF0FF == 0xF01E:
+		return fmt.Sprintf("ADD I, V%X", x)
+	case instruction&0xF0FF == 0xF029:
+		return fmt.Sprintf("LD F, V%X", x)
+	case instruction&0xF0FF == 0xF030:
+		return fmt.Sprintf("LD HF, V%X", x)
+	case instruction&0xF0FF == 0xF033:
+		return fmt.Sprintf("LD B, V%X", x)
+	case instruction&0xF0FF == 0xF03A:
+		return fmt.Sprintf("PITCH V%X", x)
+	case instruction&0xF0FF == 0xF055:
+		return fmt.Sprintf("LD [I], V%X", x)
+	case instruction&0xF0FF == 0xF065:
+		return fmt.Sprintf("LD V%X, [I]", x)
+	case instruction&0xF0FF == 0xF075:
+		return fmt.Sprintf("LD R, V%X", x)
+	case instruction&0xF0FF == 0xF085:
+		return fmt.Sprintf("LD V%X, R", x)
+	default:
+		return fmt.Sprintf("DW 0x%04X", instruction)
+	}
+}
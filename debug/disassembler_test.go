@@ -0,0 +1,39 @@
+package debug
+
+import "testing"
+
+func TestDisassembleKnownInstructions(t *testing.T) {
+	cases := []struct {
+		instruction uint16
+		want        string
+	}{
+		{0x00E0, "CLS"},
+		{0x00EE, "RET"},
+		{0x00FD, "EXIT"},
+		{0x1234, "JP 0x234"},
+		{0x2345, "CALL 0x345"},
+		{0x6A12, "LD VA, 0x12"},
+		{0x8120, "LD V1, V2"},
+		{0x8126, "SHR V1 {, V2}"},
+		{0xA123, "LD I, 0x123"},
+		{0xD125, "DRW V1, V2, 5"},
+		{0xF00A, "LD V0, K"},
+		{0xF255, "LD [I], V2"},
+	}
+
+	for _, tc := range cases {
+		if got := Disassemble(tc.instruction); got != tc.want {
+			t.Errorf("Disassemble(%#04x) = %q, want %q", tc.instruction, got, tc.want)
+		}
+	}
+}
+
+func TestDisassembleUnrecognisedInstruction(t *testing.T) {
+	// 0x5001 isn't a valid 5xy0 (low nibble must be 0) and matches no other
+	// pattern, so it should fall through to the raw-data-word rendering.
+	got := Disassemble(0x5001)
+	want := "DW 0x5001"
+	if got != want {
+		t.Errorf("Disassemble(0x5001) = %q, want %q", got, want)
+	}
+}
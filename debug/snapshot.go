@@ -0,0 +1,72 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/gob"
+	"log"
+
+	"github.com/andrepcg/chip-8-emu/chip8"
+)
+
+// Snapshot is a gob-encoded copy of a chip8.VM's state, taken at a single
+// point in time.
+type Snapshot struct {
+	data []byte
+}
+
+// Snap gob-encodes vm's state (via VM.GobEncode, which also captures its
+// unexported scheduler fields) into a Snapshot. Panics only if gob
+// itself cannot encode the VM, which would indicate a programming error
+// (an unsupported field type), not a runtime condition callers should
+// handle.
+func Snap(vm *chip8.VM) Snapshot {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(vm); err != nil {
+		log.Panicf("debug: failed to snapshot VM: %v", err)
+	}
+	return Snapshot{data: buf.Bytes()}
+}
+
+// Restore decodes the snapshot back into vm, overwriting its current state.
+func (s Snapshot) Restore(vm *chip8.VM) {
+	if err := gob.NewDecoder(bytes.NewReader(s.data)).Decode(vm); err != nil {
+		log.Panicf("debug: failed to restore VM snapshot: %v", err)
+	}
+}
+
+// Ring is a fixed-size circular buffer of Snapshots used to implement
+// rewind: once full, each Push overwrites the oldest entry.
+type Ring struct {
+	snapshots []Snapshot
+	next      int
+	count     int
+}
+
+// NewRing allocates a rewind ring holding up to capacity snapshots (e.g.
+// 600 = 60fps * 10s of history).
+func NewRing(capacity int) *Ring {
+	return &Ring{snapshots: make([]Snapshot, capacity)}
+}
+
+func (r *Ring) Push(s Snapshot) {
+	r.snapshots[r.next] = s
+	r.next = (r.next + 1) % len(r.snapshots)
+	if r.count < len(r.snapshots) {
+		r.count++
+	}
+}
+
+// Back returns the snapshot taken `steps` Pushes ago (1 = the most recent),
+// or nil if there isn't that much history.
+func (r *Ring) Back(steps int) *Snapshot {
+	if steps <= 0 || steps > r.count {
+		return nil
+	}
+	idx := (r.next - steps + len(r.snapshots)) % len(r.snapshots)
+	return &r.snapshots[idx]
+}
+
+// Len reports how many snapshots are currently stored.
+func (r *Ring) Len() int {
+	return r.count
+}
@@ -0,0 +1,55 @@
+package debug
+
+import (
+	"testing"
+
+	"github.com/andrepcg/chip-8-emu/chip8"
+)
+
+func TestSnapRestoreRoundTrip(t *testing.T) {
+	vm := newTestVM()
+	vm.V[2] = 0x42
+	vm.PC = 0x300
+
+	snap := Snap(vm)
+
+	vm.V[2] = 0x00
+	vm.PC = 0x400
+	snap.Restore(vm)
+
+	if vm.V[2] != 0x42 {
+		t.Fatalf("V[2] = %#x, want 0x42 (restored from snapshot)", vm.V[2])
+	}
+	if vm.PC != 0x300 {
+		t.Fatalf("PC = %#x, want 0x300 (restored from snapshot)", vm.PC)
+	}
+}
+
+func TestRingOverwritesOldestOnceFull(t *testing.T) {
+	r := NewRing(3)
+	vm := &chip8.VM{RAM: make([]byte, chip8.ClassicRamSize)}
+
+	for pc := uint16(1); pc <= 4; pc++ {
+		vm.PC = pc
+		r.Push(Snap(vm))
+	}
+
+	if r.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3 (capacity caps count even after 4 pushes)", r.Len())
+	}
+
+	// Most recent push (PC=4) is 1 step back; PC=1 should have been evicted.
+	back1 := r.Back(1)
+	if back1 == nil {
+		t.Fatal("Back(1) = nil, want the most recent snapshot")
+	}
+	vm.PC = 0
+	back1.Restore(vm)
+	if vm.PC != 4 {
+		t.Fatalf("Back(1).PC = %#x, want 4", vm.PC)
+	}
+
+	if r.Back(4) != nil {
+		t.Fatal("Back(4) = non-nil, want nil (only 3 snapshots of history are kept)")
+	}
+}